@@ -4,174 +4,315 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
 	netv1 "k8s.io/api/networking/v1"
-	"k8s.io/client-go/informers"
+	k8sruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 )
 
 const annotationKey = "mdnscontroller/enabled"
 
+// defaultWorkers is the number of goroutines draining the workqueue.
+const defaultWorkers = 2
+
 type HostHandler interface {
-	OnHostsAdded(hosts []string)
-	OnHostsRemoved(hosts []string)
+	OnHostsAdded(hosts []HostSpec) error
+	OnHostsRemoved(hosts []HostSpec) error
+}
+
+// Source is one kind of Kubernetes object the controller watches for
+// desired mDNS hosts, e.g. Ingress or a Gateway API HTTPRoute. Each Source
+// owns its own informer and knows how to compute the desired host set for
+// one of its objects; MDNSController aggregates across every Source,
+// refcounting hosts so two sources claiming the same host only register or
+// unregister it once.
+type Source struct {
+	// Kind identifies the source for refcounting and logging, e.g. "Ingress".
+	Kind string
+	// Informer backs this source; the controller waits for it to sync and
+	// subscribes to its add/update/delete events.
+	Informer cache.SharedIndexInformer
+	// Hosts returns the HostSpecs that should be registered for the object
+	// identified by namespace/name, or nil if it no longer exists or isn't
+	// enabled for mDNS.
+	Hosts func(namespace, name string) ([]HostSpec, error)
+}
+
+// objectKey identifies a single object across all watched sources.
+type objectKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+func (k objectKey) String() string {
+	return fmt.Sprintf("%s/%s/%s", k.kind, k.namespace, k.name)
 }
 
 type MDNSController struct {
-	informerFactory informers.SharedInformerFactory
-	ingressInformer cache.SharedIndexInformer
-	handler         HostHandler
-	logger          *slog.Logger
+	sources []Source
+	handler HostHandler
+	logger  *slog.Logger
+
+	workers int
+	queue   workqueue.RateLimitingInterface
+
+	registeredMu sync.Mutex
+	// registered tracks the HostSpecs last successfully registered for
+	// each object key, so syncObject can diff desired vs. actual state
+	// even after the object itself has been deleted.
+	registered map[objectKey][]HostSpec
+	// hostRefs tracks which object keys currently claim each host (by
+	// Host, not full spec), so a host claimed by more than one
+	// source/object is only registered with the handler once and only
+	// unregistered once every claim is gone.
+	hostRefs map[string]map[objectKey]struct{}
 }
 
 func NewMDNS(
-	factory informers.SharedInformerFactory,
+	sources []Source,
 	handler HostHandler,
 	logger *slog.Logger,
 ) *MDNSController {
-	ingressInformer := factory.Networking().V1().Ingresses().Informer()
-
 	c := &MDNSController{
-		informerFactory: factory,
-		ingressInformer: ingressInformer,
-		handler:         handler,
-		logger:          logger,
+		sources:    sources,
+		handler:    handler,
+		logger:     logger,
+		workers:    defaultWorkers,
+		queue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "mdns-sources"),
+		registered: make(map[objectKey][]HostSpec),
+		hostRefs:   make(map[string]map[objectKey]struct{}),
 	}
 
-	_, err := ingressInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    c.onAdd,
-		UpdateFunc: c.onUpdate,
-		DeleteFunc: c.onDelete,
-	})
-	if err != nil {
-		logger.Error("add event handler", "err", err)
+	for _, source := range sources {
+		kind := source.Kind
+		_, err := source.Informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj any) { c.enqueue(kind, obj) },
+			UpdateFunc: func(_, newObj any) { c.enqueue(kind, newObj) },
+			DeleteFunc: func(obj any) { c.enqueue(kind, obj) },
+		})
+		if err != nil {
+			logger.Error("add event handler", "kind", kind, "err", err)
+		}
 	}
 
 	return c
 }
 
+func (c *MDNSController) enqueue(kind string, obj any) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		c.logger.Error("compute key for object", "kind", kind, "err", err)
+		return
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		c.logger.Error("split key", "kind", kind, "key", key, "err", err)
+		return
+	}
+
+	c.queue.Add(objectKey{kind: kind, namespace: namespace, name: name})
+}
+
 func (c *MDNSController) Run(ctx context.Context) error {
+	defer k8sruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
 	c.logger.Info("Starting mDNS Controller")
 
-	c.informerFactory.Start(ctx.Done())
+	hasSynced := make([]cache.InformerSynced, 0, len(c.sources))
+	for _, source := range c.sources {
+		go source.Informer.Run(ctx.Done())
+		hasSynced = append(hasSynced, source.Informer.HasSynced)
+	}
 
 	c.logger.Info("Waiting for informer caches to sync")
-	if !cache.WaitForCacheSync(ctx.Done(), c.ingressInformer.HasSynced) {
-		return fmt.Errorf("sync informer cache")
+	if !cache.WaitForCacheSync(ctx.Done(), hasSynced...) {
+		return fmt.Errorf("sync informer caches")
 	}
 
-	c.logger.Info("Controller synced and ready")
+	c.logger.Info("Controller synced and ready", "workers", c.workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer k8sruntime.HandleCrash()
+			wait.Until(c.runWorker, time.Second, ctx.Done())
+		}()
+	}
 
 	<-ctx.Done()
 	c.logger.Info("Shutting down controller")
+	wg.Wait()
 	return nil
 }
 
-func (c *MDNSController) onAdd(obj any) {
-	ing, ok := obj.(*netv1.Ingress)
-	if !ok {
-		return
+func (c *MDNSController) runWorker() {
+	for c.processNextWorkItem() {
 	}
+}
 
-	if !isEnabled(ing) {
-		return
+func (c *MDNSController) processNextWorkItem() bool {
+	item, quit := c.queue.Get()
+	if quit {
+		return false
 	}
+	defer c.queue.Done(item)
 
-	hosts := extractHosts(ing)
-	if len(hosts) > 0 {
-		c.logger.Info("Ingress added with enabled annotation", "name", ing.Name, "hosts", hosts)
-		c.handler.OnHostsAdded(hosts)
+	key := item.(objectKey)
+	if err := c.syncObject(key); err != nil {
+		c.logger.Error("sync object, requeueing", "key", key, "err", err)
+		c.queue.AddRateLimited(item)
+		return true
 	}
+
+	c.queue.Forget(item)
+	return true
 }
 
-func (c *MDNSController) onUpdate(oldObj, newObj interface{}) {
-	oldIng, ok1 := oldObj.(*netv1.Ingress)
-	newIng, ok2 := newObj.(*netv1.Ingress)
-	if !ok1 || !ok2 {
-		return
+// syncObject reconciles the desired mDNS hosts for a single object against
+// what was last registered, registering newly-claimed or changed hosts and
+// unregistering ones no longer claimed by anyone.
+func (c *MDNSController) syncObject(key objectKey) error {
+	var source *Source
+	for i := range c.sources {
+		if c.sources[i].Kind == key.kind {
+			source = &c.sources[i]
+			break
+		}
+	}
+	if source == nil {
+		return fmt.Errorf("no source registered for kind %q", key.kind)
 	}
 
-	wasEnabled := isEnabled(oldIng)
-	isEnabled := isEnabled(newIng)
-
-	oldHosts := extractHosts(oldIng)
-	newHosts := extractHosts(newIng)
-
-	if !wasEnabled && isEnabled {
-		// Case 1: Annotation enabled (Disabled -> Enabled)
-		c.logger.Info("Annotation enabled on existing ingress", "name", newIng.Name, "hosts", newHosts)
-		c.handler.OnHostsAdded(newHosts)
-	} else if wasEnabled && !isEnabled {
-		// Case 2: Annotation disabled (Enabled -> Disabled)
-		c.logger.Info("Annotation disabled on existing ingress", "name", newIng.Name)
-		c.handler.OnHostsRemoved(oldHosts)
-	} else if isEnabled {
-		// Case 3: Still enabled, check for specific host changes
-		added, removed := calculateHostDiff(oldHosts, newHosts)
+	desired, err := source.Hosts(key.namespace, key.name)
+	if err != nil {
+		return fmt.Errorf("compute desired hosts for %s: %w", key, err)
+	}
 
-		if len(added) > 0 || len(removed) > 0 {
-			c.logger.Info("Hosts updated", "name", newIng.Name, "added", added, "removed", removed)
+	return c.applyDiff(key, desired)
+}
 
-			// Unregister only the removed hosts
-			if len(removed) > 0 {
-				c.handler.OnHostsRemoved(removed)
-			}
+// applyDiff registers/unregisters hosts so the handler ends up advertising
+// the union of every object's desired HostSpecs, and records the outcome in
+// c.registered/c.hostRefs. A host spec change (e.g. an annotation edit) is
+// treated as the old spec being removed and the new one added. The handler
+// is only called for a host transition (nobody claims it -> this object's
+// spec now represents it, or vice versa); a host already claimed by another
+// object under a different spec is left alone.
+//
+// registeredMu is held for the entire read-decide-commit sequence,
+// including the handler calls: releasing it in between (as an earlier
+// version did) lets two objects that both drop the same host at once each
+// see themselves as a non-last owner and both skip calling the handler,
+// leaking a registration nobody ever unregisters. Serializing on the lock
+// costs throughput but keeps the refcount decisions correct.
+func (c *MDNSController) applyDiff(key objectKey, desired []HostSpec) error {
+	c.registeredMu.Lock()
+	defer c.registeredMu.Unlock()
+
+	current := c.registered[key]
+	added, removed := calculateHostDiff(current, desired)
+
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
 
-			// Register only the new hosts
-			if len(added) > 0 {
-				c.handler.OnHostsAdded(added)
+	var toRegister, toUnregister []HostSpec
+	for _, spec := range removed {
+		if refs, claimed := c.hostRefs[spec.Host]; claimed && len(refs) == 1 {
+			if _, ownsIt := refs[key]; ownsIt {
+				toUnregister = append(toUnregister, spec)
 			}
 		}
 	}
-}
-
-// calculateHostDiff returns the hosts that are in newList but not oldList (added),
-// and hosts in oldList but not newList (removed).
-func calculateHostDiff(oldList, newList []string) (added, removed []string) {
-	oldSet := make(map[string]struct{}, len(oldList))
-	for _, h := range oldList {
-		oldSet[h] = struct{}{}
+	for _, spec := range added {
+		refs, claimed := c.hostRefs[spec.Host]
+		if !claimed {
+			toRegister = append(toRegister, spec)
+			continue
+		}
+		// Only this object claims the host already (e.g. it's
+		// re-registering with a changed spec); anyone else's claim wins.
+		if _, ownsIt := refs[key]; ownsIt && len(refs) == 1 {
+			toRegister = append(toRegister, spec)
+		}
 	}
 
-	newSet := make(map[string]struct{}, len(newList))
-	for _, h := range newList {
-		newSet[h] = struct{}{}
+	if len(toUnregister) > 0 {
+		c.logger.Info("Unregistering hosts", "key", key, "hosts", toUnregister)
+		if err := c.handler.OnHostsRemoved(toUnregister); err != nil {
+			return fmt.Errorf("unregister hosts for %s: %w", key, err)
+		}
 	}
 
-	for _, h := range newList {
-		if _, exists := oldSet[h]; !exists {
-			added = append(added, h)
+	if len(toRegister) > 0 {
+		c.logger.Info("Registering hosts", "key", key, "hosts", toRegister)
+		if err := c.handler.OnHostsAdded(toRegister); err != nil {
+			return fmt.Errorf("register hosts for %s: %w", key, err)
 		}
 	}
 
-	// Find removed: present in old, missing from new
-	for _, h := range oldList {
-		if _, exists := newSet[h]; !exists {
-			removed = append(removed, h)
+	for _, spec := range removed {
+		if refs, ok := c.hostRefs[spec.Host]; ok {
+			delete(refs, key)
+			if len(refs) == 0 {
+				delete(c.hostRefs, spec.Host)
+			}
 		}
 	}
+	for _, spec := range added {
+		refs, ok := c.hostRefs[spec.Host]
+		if !ok {
+			refs = make(map[objectKey]struct{})
+			c.hostRefs[spec.Host] = refs
+		}
+		refs[key] = struct{}{}
+	}
+	if len(desired) == 0 {
+		delete(c.registered, key)
+	} else {
+		c.registered[key] = desired
+	}
 
-	return added, removed
+	return nil
 }
 
-func (c *MDNSController) onDelete(obj any) {
-	ing, ok := obj.(*netv1.Ingress)
-	if !ok {
-		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
-		if !ok {
-			return
-		}
-		ing, ok = tombstone.Obj.(*netv1.Ingress)
-		if !ok {
-			return
+// calculateHostDiff returns the specs that are in newList but not oldList
+// (added), and the specs in oldList but not newList (removed), comparing by
+// Host so that a spec whose other fields changed shows up as both added
+// (the new spec) and removed (the old one).
+func calculateHostDiff(oldList, newList []HostSpec) (added, removed []HostSpec) {
+	oldByHost := make(map[string]HostSpec, len(oldList))
+	for _, s := range oldList {
+		oldByHost[s.Host] = s
+	}
+
+	newByHost := make(map[string]HostSpec, len(newList))
+	for _, s := range newList {
+		newByHost[s.Host] = s
+	}
+
+	for _, s := range newList {
+		if old, exists := oldByHost[s.Host]; !exists || old != s {
+			added = append(added, s)
 		}
 	}
 
-	if isEnabled(ing) {
-		hosts := extractHosts(ing)
-		c.logger.Info("Ingress deleted", "name", ing.Name, "hosts", hosts)
-		c.handler.OnHostsRemoved(hosts)
+	for _, s := range oldList {
+		if newSpec, exists := newByHost[s.Host]; !exists || newSpec != s {
+			removed = append(removed, s)
+		}
 	}
+
+	return added, removed
 }
 
 // isEnabled checks the annotation
@@ -181,14 +322,3 @@ func isEnabled(ing *netv1.Ingress) bool {
 	}
 	return ing.Annotations[annotationKey] == "true"
 }
-
-// extractHosts pulls hostnames from Ingress rules
-func extractHosts(ing *netv1.Ingress) []string {
-	var hosts []string
-	for _, rule := range ing.Spec.Rules {
-		if rule.Host != "" {
-			hosts = append(hosts, rule.Host)
-		}
-	}
-	return hosts
-}
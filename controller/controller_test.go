@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// countingHandler records how many times each host was (un)registered, so
+// tests can assert the handler is called exactly once per transition even
+// when multiple objects race to claim or release the same host.
+type countingHandler struct {
+	mu      sync.Mutex
+	added   map[string]int
+	removed map[string]int
+}
+
+func newCountingHandler() *countingHandler {
+	return &countingHandler{
+		added:   make(map[string]int),
+		removed: make(map[string]int),
+	}
+}
+
+func (h *countingHandler) OnHostsAdded(hosts []HostSpec) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, s := range hosts {
+		h.added[s.Host]++
+	}
+	return nil
+}
+
+func (h *countingHandler) OnHostsRemoved(hosts []HostSpec) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, s := range hosts {
+		h.removed[s.Host]++
+	}
+	return nil
+}
+
+func newTestController(handler HostHandler) *MDNSController {
+	return NewMDNS(nil, handler, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// TestApplyDiffConcurrentLastOwnerRelease covers the refcounting race
+// called out in review: an Ingress and an HTTPRoute both claim the same
+// host, then both are torn down at (almost) the same time. The handler
+// must be told to unregister the host exactly once, not zero times
+// (a leaked/phantom advertisement) and not twice.
+func TestApplyDiffConcurrentLastOwnerRelease(t *testing.T) {
+	handler := newCountingHandler()
+	c := newTestController(handler)
+
+	spec := HostSpec{Host: "shared.example.com", ServiceType: defaultServiceType, Port: defaultHTTPPort, InstanceName: "shared.example.com"}
+	ingressKey := objectKey{kind: "Ingress", namespace: "default", name: "ing"}
+	routeKey := objectKey{kind: "HTTPRoute", namespace: "default", name: "route"}
+
+	if err := c.applyDiff(ingressKey, []HostSpec{spec}); err != nil {
+		t.Fatalf("register via ingress: %v", err)
+	}
+	if err := c.applyDiff(routeKey, []HostSpec{spec}); err != nil {
+		t.Fatalf("register via route: %v", err)
+	}
+	if got := handler.added[spec.Host]; got != 1 {
+		t.Fatalf("host should be registered once when first claimed, got %d", got)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for _, key := range []objectKey{ingressKey, routeKey} {
+		key := key
+		go func() {
+			defer wg.Done()
+			if err := c.applyDiff(key, nil); err != nil {
+				t.Errorf("tear down %s: %v", key, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := handler.removed[spec.Host]; got != 1 {
+		t.Fatalf("expected exactly one OnHostsRemoved call for %s once all owners are gone, got %d", spec.Host, got)
+	}
+
+	c.registeredMu.Lock()
+	_, stillClaimed := c.hostRefs[spec.Host]
+	c.registeredMu.Unlock()
+	if stillClaimed {
+		t.Fatalf("hostRefs should have no entry for %s once all owners released it", spec.Host)
+	}
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCalculateHostDiff(t *testing.T) {
+	httpSpec := func(host string) HostSpec {
+		return HostSpec{Host: host, ServiceType: defaultServiceType, Port: defaultHTTPPort, InstanceName: host}
+	}
+
+	tests := []struct {
+		name        string
+		oldList     []HostSpec
+		newList     []HostSpec
+		wantAdded   []HostSpec
+		wantRemoved []HostSpec
+	}{
+		{
+			name:      "host added",
+			oldList:   nil,
+			newList:   []HostSpec{httpSpec("a.example.com")},
+			wantAdded: []HostSpec{httpSpec("a.example.com")},
+		},
+		{
+			name:        "host removed",
+			oldList:     []HostSpec{httpSpec("a.example.com")},
+			newList:     nil,
+			wantRemoved: []HostSpec{httpSpec("a.example.com")},
+		},
+		{
+			name:    "unchanged host produces no diff",
+			oldList: []HostSpec{httpSpec("a.example.com")},
+			newList: []HostSpec{httpSpec("a.example.com")},
+		},
+		{
+			name:        "annotation change re-registers the host",
+			oldList:     []HostSpec{httpSpec("a.example.com")},
+			newList:     []HostSpec{{Host: "a.example.com", ServiceType: "_printer._tcp", Port: defaultHTTPPort, InstanceName: "a.example.com"}},
+			wantAdded:   []HostSpec{{Host: "a.example.com", ServiceType: "_printer._tcp", Port: defaultHTTPPort, InstanceName: "a.example.com"}},
+			wantRemoved: []HostSpec{httpSpec("a.example.com")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := calculateHostDiff(tt.oldList, tt.newList)
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Fatalf("added = %#v, want %#v", added, tt.wantAdded)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Fatalf("removed = %#v, want %#v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions"
+	gwapilisters "sigs.k8s.io/gateway-api/pkg/client/listers/apis/v1"
+)
+
+// NewGatewaySource watches Gateway API Gateways and advertises every
+// listener hostname of a Gateway carrying the mdnscontroller/enabled
+// annotation.
+func NewGatewaySource(factory gwapiinformers.SharedInformerFactory) Source {
+	lister := factory.Gateway().V1().Gateways().Lister()
+
+	return Source{
+		Kind:     "Gateway",
+		Informer: factory.Gateway().V1().Gateways().Informer(),
+		Hosts: func(namespace, name string) ([]HostSpec, error) {
+			gw, err := lister.Gateways(namespace).Get(name)
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("get gateway %s/%s: %w", namespace, name, err)
+			}
+
+			if !isGatewayEnabled(gw) {
+				return nil, nil
+			}
+
+			var specs []HostSpec
+			for _, listener := range gw.Spec.Listeners {
+				if listener.Hostname != nil {
+					specs = append(specs, defaultHostSpec(string(*listener.Hostname)))
+				}
+			}
+			return specs, nil
+		},
+	}
+}
+
+// NewHTTPRouteSource watches Gateway API HTTPRoutes and advertises
+// spec.hostnames for routes that (or whose parent Gateway) carry the
+// mdnscontroller/enabled annotation.
+//
+// TLSRoute/TCPRoute attach to Gateways the same way and could get a Source
+// of their own following this exact pattern; they're left for a follow-up
+// since TCPRoute in particular carries no hostname to advertise.
+func NewHTTPRouteSource(factory gwapiinformers.SharedInformerFactory, gatewayLister gwapilisters.GatewayLister) Source {
+	lister := factory.Gateway().V1().HTTPRoutes().Lister()
+
+	return Source{
+		Kind:     "HTTPRoute",
+		Informer: factory.Gateway().V1().HTTPRoutes().Informer(),
+		Hosts: func(namespace, name string) ([]HostSpec, error) {
+			route, err := lister.HTTPRoutes(namespace).Get(name)
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("get httproute %s/%s: %w", namespace, name, err)
+			}
+
+			if !isHTTPRouteEnabled(route, gatewayLister) {
+				return nil, nil
+			}
+
+			specs := make([]HostSpec, 0, len(route.Spec.Hostnames))
+			for _, h := range route.Spec.Hostnames {
+				specs = append(specs, defaultHostSpec(string(h)))
+			}
+			return specs, nil
+		},
+	}
+}
+
+func isGatewayEnabled(gw *gatewayv1.Gateway) bool {
+	return gw.Annotations[annotationKey] == "true"
+}
+
+// isHTTPRouteEnabled is true if the route itself carries
+// mdnscontroller/enabled, or any Gateway it attaches to does.
+func isHTTPRouteEnabled(route *gatewayv1.HTTPRoute, gatewayLister gwapilisters.GatewayLister) bool {
+	if route.Annotations[annotationKey] == "true" {
+		return true
+	}
+
+	for _, parent := range route.Spec.ParentRefs {
+		namespace := route.Namespace
+		if parent.Namespace != nil {
+			namespace = string(*parent.Namespace)
+		}
+
+		gw, err := gatewayLister.Gateways(namespace).Get(string(parent.Name))
+		if err != nil {
+			continue
+		}
+		if isGatewayEnabled(gw) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	netv1 "k8s.io/api/networking/v1"
+)
+
+const (
+	serviceTypeAnnotation  = "mdnscontroller/service-type"
+	portAnnotation         = "mdnscontroller/port"
+	txtAnnotation          = "mdnscontroller/txt"
+	instanceNameAnnotation = "mdnscontroller/instance-name"
+
+	defaultServiceType = "_http._tcp"
+	defaultHTTPSPort   = 443
+	defaultHTTPPort    = 80
+)
+
+// HostSpec is everything needed to advertise one host over mDNS: which
+// service type and port to bind it to, and an optional TXT payload and
+// instance name. It's comparable so the controller can diff a source's
+// desired specs against what it last registered.
+type HostSpec struct {
+	Host         string
+	ServiceType  string
+	Port         int
+	TXT          string // comma-separated k=v pairs, as given in the annotation
+	InstanceName string
+}
+
+// defaultHostSpec is used by sources that have no annotation-driven
+// configuration of their own (Gateway, HTTPRoute): the default service type
+// on port 443 (defaultHTTPSPort) regardless of TLS, no TXT records.
+func defaultHostSpec(host string) HostSpec {
+	return HostSpec{
+		Host:         host,
+		ServiceType:  defaultServiceType,
+		Port:         defaultHTTPSPort,
+		InstanceName: host,
+	}
+}
+
+// extractHostSpecs pulls hostnames from Ingress rules, combined with the
+// service-type/port/txt/instance-name annotations (which apply to every
+// host on the Ingress).
+func extractHostSpecs(ing *netv1.Ingress) []HostSpec {
+	serviceType := ing.Annotations[serviceTypeAnnotation]
+	if serviceType == "" {
+		serviceType = defaultServiceType
+	}
+
+	port := defaultPort(ing)
+	if raw, ok := ing.Annotations[portAnnotation]; ok {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			port = parsed
+		}
+	}
+
+	txt := ing.Annotations[txtAnnotation]
+	instanceName := ing.Annotations[instanceNameAnnotation]
+	multiHost := countHosts(ing) > 1
+
+	var specs []HostSpec
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host == "" {
+			continue
+		}
+
+		name := instanceName
+		switch {
+		case name == "":
+			name = rule.Host
+		case multiHost:
+			// An explicit instance-name annotation applies to every rule on
+			// the Ingress, so on a multi-host Ingress it would otherwise
+			// produce colliding "<instance>.<type>.local" records for
+			// different hosts. Disambiguate by suffixing the host.
+			name = instanceName + "-" + rule.Host
+		}
+
+		specs = append(specs, HostSpec{
+			Host:         rule.Host,
+			ServiceType:  serviceType,
+			Port:         port,
+			TXT:          txt,
+			InstanceName: name,
+		})
+	}
+	return specs
+}
+
+// countHosts returns how many rules on the Ingress carry a non-empty host.
+func countHosts(ing *netv1.Ingress) int {
+	n := 0
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// defaultPort is 443 if the Ingress has any TLS entries, 80 otherwise.
+func defaultPort(ing *netv1.Ingress) int {
+	if len(ing.Spec.TLS) > 0 {
+		return defaultHTTPSPort
+	}
+	return defaultHTTPPort
+}
+
+// TXTPairs splits the comma-separated TXT annotation into individual "k=v"
+// records, ready to hand to dns-sd/avahi-publish/the native mDNS server.
+func (s HostSpec) TXTPairs() []string {
+	if s.TXT == "" {
+		return nil
+	}
+
+	parts := strings.Split(s.TXT, ",")
+	pairs := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs
+}
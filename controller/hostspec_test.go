@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExtractHostSpecsDefaults(t *testing.T) {
+	ing := &netv1.Ingress{
+		Spec: netv1.IngressSpec{
+			Rules: []netv1.IngressRule{{Host: "app.example.com"}},
+		},
+	}
+
+	got := extractHostSpecs(ing)
+	want := []HostSpec{{
+		Host:         "app.example.com",
+		ServiceType:  defaultServiceType,
+		Port:         defaultHTTPPort,
+		InstanceName: "app.example.com",
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("extractHostSpecs() = %#v, want %#v", got, want)
+	}
+}
+
+func TestExtractHostSpecsTLSDefaultsToHTTPSPort(t *testing.T) {
+	ing := &netv1.Ingress{
+		Spec: netv1.IngressSpec{
+			Rules: []netv1.IngressRule{{Host: "app.example.com"}},
+			TLS:   []netv1.IngressTLS{{Hosts: []string{"app.example.com"}}},
+		},
+	}
+
+	got := extractHostSpecs(ing)
+	if len(got) != 1 || got[0].Port != defaultHTTPSPort {
+		t.Fatalf("extractHostSpecs() = %#v, want port %d", got, defaultHTTPSPort)
+	}
+}
+
+func TestExtractHostSpecsAnnotations(t *testing.T) {
+	ing := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				serviceTypeAnnotation:  "_printer._tcp",
+				portAnnotation:         "8080",
+				txtAnnotation:          "path=/a, version=1",
+				instanceNameAnnotation: "my-printer",
+			},
+		},
+		Spec: netv1.IngressSpec{
+			Rules: []netv1.IngressRule{{Host: "printer.example.com"}},
+		},
+	}
+
+	got := extractHostSpecs(ing)
+	want := []HostSpec{{
+		Host:         "printer.example.com",
+		ServiceType:  "_printer._tcp",
+		Port:         8080,
+		TXT:          "path=/a, version=1",
+		InstanceName: "my-printer",
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("extractHostSpecs() = %#v, want %#v", got, want)
+	}
+}
+
+// TestExtractHostSpecsMultiHostInstanceNameDisambiguated covers the
+// collision called out in review: an explicit instance-name annotation
+// applies to every rule on the Ingress, so on a multi-host Ingress it must
+// be suffixed per host rather than producing two identical
+// "<instance>.<type>.local" records for different hosts.
+func TestExtractHostSpecsMultiHostInstanceNameDisambiguated(t *testing.T) {
+	ing := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{instanceNameAnnotation: "shared"},
+		},
+		Spec: netv1.IngressSpec{
+			Rules: []netv1.IngressRule{
+				{Host: "a.example.com"},
+				{Host: "b.example.com"},
+			},
+		},
+	}
+
+	got := extractHostSpecs(ing)
+	if len(got) != 2 {
+		t.Fatalf("extractHostSpecs() returned %d specs, want 2", len(got))
+	}
+	if got[0].InstanceName == got[1].InstanceName {
+		t.Fatalf("expected distinct instance names, both got %q", got[0].InstanceName)
+	}
+	if got[0].InstanceName != "shared-a.example.com" || got[1].InstanceName != "shared-b.example.com" {
+		t.Fatalf("extractHostSpecs() instance names = %q, %q", got[0].InstanceName, got[1].InstanceName)
+	}
+}
+
+func TestExtractHostSpecsSkipsEmptyHost(t *testing.T) {
+	ing := &netv1.Ingress{
+		Spec: netv1.IngressSpec{
+			Rules: []netv1.IngressRule{{Host: ""}, {Host: "app.example.com"}},
+		},
+	}
+
+	got := extractHostSpecs(ing)
+	if len(got) != 1 || got[0].Host != "app.example.com" {
+		t.Fatalf("extractHostSpecs() = %#v, want a single spec for app.example.com", got)
+	}
+}
+
+func TestTXTPairs(t *testing.T) {
+	tests := []struct {
+		name string
+		txt  string
+		want []string
+	}{
+		{name: "empty", txt: "", want: nil},
+		{name: "single", txt: "k=v", want: []string{"k=v"}},
+		{name: "multiple with spaces", txt: "a=1, b=2 ,c=3", want: []string{"a=1", "b=2", "c=3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := HostSpec{TXT: tt.txt}
+			if got := spec.TXTPairs(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("TXTPairs() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
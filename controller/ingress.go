@@ -0,0 +1,37 @@
+package controller
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/informers"
+	netv1listers "k8s.io/client-go/listers/networking/v1"
+)
+
+// NewIngressSource watches Ingresses and advertises the hosts from every
+// rule of an Ingress carrying the mdnscontroller/enabled annotation.
+func NewIngressSource(factory informers.SharedInformerFactory) Source {
+	lister := factory.Networking().V1().Ingresses().Lister()
+
+	return Source{
+		Kind:     "Ingress",
+		Informer: factory.Networking().V1().Ingresses().Informer(),
+		Hosts:    ingressHosts(lister),
+	}
+}
+
+func ingressHosts(lister netv1listers.IngressLister) func(namespace, name string) ([]HostSpec, error) {
+	return func(namespace, name string) ([]HostSpec, error) {
+		ing, err := lister.Ingresses(namespace).Get(name)
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !isEnabled(ing) {
+			return nil, nil
+		}
+
+		return extractHostSpecs(ing), nil
+	}
+}
@@ -0,0 +1,56 @@
+package ipresolver
+
+import (
+	"context"
+	"log/slog"
+	"net"
+)
+
+// InterfaceScanResolver serves the first non-loopback IPv4 address found on
+// the machine running the binary. It's the fallback used when neither
+// --publish-service nor --publish-address is set, which is correct for a
+// controller running directly on the host but wrong once it runs inside a
+// pod whose traffic actually lands on a Service elsewhere.
+type InterfaceScanResolver struct {
+	baseResolver
+}
+
+// NewInterfaceScanResolver scans local interfaces for an address to use,
+// unless explicit is true, in which case the caller passed --ip-address on
+// purpose (e.g. to work around the scan picking the wrong NIC on a
+// multi-homed host) and that value is used as-is, scan skipped entirely.
+func NewInterfaceScanResolver(logger *slog.Logger, fallback string, explicit bool) *InterfaceScanResolver {
+	r := &InterfaceScanResolver{}
+	address := fallback
+	if !explicit {
+		address = scanLocalAddress(logger, fallback)
+	}
+	r.set([]string{address})
+	return r
+}
+
+// Run is a no-op: the scan happens once, at construction time.
+func (r *InterfaceScanResolver) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func scanLocalAddress(logger *slog.Logger, fallback string) string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		logger.Error("List interface addresses", "err", err)
+		return fallback
+	}
+
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
+			if ipv4 := ipnet.IP.To4(); ipv4 != nil {
+				logger.Info("Using ip for mDNS", "ip", ipv4.String())
+				return ipv4.String()
+			}
+		}
+	}
+
+	logger.Warn("No IPv4 addresses found")
+	return fallback
+}
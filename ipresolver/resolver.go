@@ -0,0 +1,73 @@
+package ipresolver
+
+import (
+	"context"
+	"sync"
+)
+
+// IPResolver is a live source of addresses to advertise over mDNS. Unlike a
+// one-shot lookup, it keeps serving the most recently observed set and lets
+// callers subscribe to changes, so hosts already registered can be
+// re-published with a new IP set without restarting the controller.
+type IPResolver interface {
+	// Addresses returns the current set of addresses to advertise.
+	Addresses() []string
+
+	// Subscribe registers a callback invoked with the new address set
+	// whenever it changes. It is not called with the initial value.
+	Subscribe(onChange func(addresses []string))
+
+	// Run starts the resolver's background watch, if it has one, and
+	// blocks until ctx is done. Resolvers with nothing to watch (e.g.
+	// a static list) just wait for ctx and then return nil.
+	Run(ctx context.Context) error
+}
+
+// baseResolver implements the subscribe/notify bookkeeping shared by every
+// IPResolver so each implementation only has to track its own addresses and
+// call set() when they change.
+type baseResolver struct {
+	mu        sync.Mutex
+	addresses []string
+	listeners []func(addresses []string)
+}
+
+func (b *baseResolver) Addresses() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.addresses
+}
+
+func (b *baseResolver) Subscribe(onChange func(addresses []string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, onChange)
+}
+
+// set updates the address list and notifies subscribers if it changed.
+func (b *baseResolver) set(addresses []string) {
+	b.mu.Lock()
+	if equalStringSlices(b.addresses, addresses) {
+		b.mu.Unlock()
+		return
+	}
+	b.addresses = addresses
+	listeners := append([]func(addresses []string){}, b.listeners...)
+	b.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(addresses)
+	}
+}
+
+func equalStringSlices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package ipresolver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ServiceResolver watches a single Service and serves the addresses from its
+// status.loadBalancer.ingress, resolving any hostnames (e.g. an AWS ELB DNS
+// name) to IPs via DNS. This is the right source when the controller runs
+// inside a pod but the ingress traffic actually lands on a LoadBalancer
+// Service elsewhere. A Service without load balancer ingress entries (e.g. a
+// plain NodePort Service) yields an empty address set.
+type ServiceResolver struct {
+	baseResolver
+
+	clientset kubernetes.Interface
+	logger    *slog.Logger
+	namespace string
+	name      string
+}
+
+func NewServiceResolver(clientset kubernetes.Interface, logger *slog.Logger, namespace, name string) *ServiceResolver {
+	return &ServiceResolver{
+		clientset: clientset,
+		logger:    logger,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+func (r *ServiceResolver) Run(ctx context.Context) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		r.clientset,
+		10*time.Minute,
+		informers.WithNamespace(r.namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fmt.Sprintf("metadata.name=%s", r.name)
+		}),
+	)
+
+	informer := factory.Core().V1().Services().Informer()
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { r.onService(obj) },
+		UpdateFunc: func(_, obj any) { r.onService(obj) },
+		DeleteFunc: func(obj any) { r.set(nil) },
+	})
+	if err != nil {
+		return fmt.Errorf("add event handler: %w", err)
+	}
+
+	defer k8sruntime.HandleCrash()
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("sync service informer for %s/%s", r.namespace, r.name)
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+func (r *ServiceResolver) onService(obj any) {
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return
+	}
+
+	var addresses []string
+	for _, lbIngress := range svc.Status.LoadBalancer.Ingress {
+		switch {
+		case lbIngress.IP != "":
+			addresses = append(addresses, lbIngress.IP)
+		case lbIngress.Hostname != "":
+			ips, err := net.LookupHost(lbIngress.Hostname)
+			if err != nil {
+				r.logger.Error("Resolve load balancer hostname", "hostname", lbIngress.Hostname, "err", err)
+				continue
+			}
+			addresses = append(addresses, ips...)
+		}
+	}
+
+	r.logger.Info("Resolved publish-service addresses", "service", fmt.Sprintf("%s/%s", r.namespace, r.name), "addresses", addresses)
+	r.set(addresses)
+}
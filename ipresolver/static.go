@@ -0,0 +1,20 @@
+package ipresolver
+
+import "context"
+
+// StaticResolver serves a fixed address list, e.g. from --publish-address.
+type StaticResolver struct {
+	baseResolver
+}
+
+func NewStaticResolver(addresses []string) *StaticResolver {
+	r := &StaticResolver{}
+	r.set(addresses)
+	return r
+}
+
+// Run is a no-op: a static list never changes.
+func (r *StaticResolver) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
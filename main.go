@@ -2,41 +2,137 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
-	"net"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/DeluxeOwl/mdnscontroller/controller"
+	"github.com/DeluxeOwl/mdnscontroller/ipresolver"
 	"github.com/DeluxeOwl/mdnscontroller/mdns"
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	gwapiversioned "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
+	gwapiinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions"
 )
 
-func GetLocalAddressOr(logger *slog.Logger, fallback string) string {
-	addrs, err := net.InterfaceAddrs()
+// gatewayAPIGroupVersion is the Gateway API group/version this controller
+// watches (Gateway and HTTPRoute are both served under v1).
+const gatewayAPIGroupVersion = "gateway.networking.k8s.io/v1"
+
+// resolveBackend turns the --backend flag into a concrete backend name,
+// resolving "auto" based on the running GOOS.
+func resolveBackend(backend, operatingSystem string) (string, error) {
+	switch backend {
+	case "dns-sd", "avahi", "native":
+		return backend, nil
+	case "auto":
+		switch operatingSystem {
+		case "darwin":
+			return "dns-sd", nil
+		case "linux":
+			return "avahi", nil
+		default:
+			return "native", nil
+		}
+	default:
+		return "", fmt.Errorf("unknown --backend %q, must be one of auto, dns-sd, avahi, native", backend)
+	}
+}
+
+// resolveGatewayAPIEnabled turns the --enable-gateway-api flag into a
+// concrete yes/no, resolving "auto" via probe. Without this, clusters that
+// don't have the Gateway API CRDs installed would hang forever waiting for
+// the Gateway/HTTPRoute informers to sync.
+func resolveGatewayAPIEnabled(mode string, probe func() (bool, error)) (bool, error) {
+	switch mode {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "auto":
+		return probe()
+	default:
+		return false, fmt.Errorf("unknown --enable-gateway-api %q, must be one of auto, true, false", mode)
+	}
+}
+
+// gatewayAPIAvailable checks the API server's discovery info for the
+// Gateway API CRDs, treating "not installed" (a straightforward NotFound,
+// or a failed group discovery because nothing serves that group) as "not
+// available" rather than an error.
+func gatewayAPIAvailable(disco discovery.DiscoveryInterface) (bool, error) {
+	_, err := disco.ServerResourcesForGroupVersion(gatewayAPIGroupVersion)
+	switch {
+	case err == nil:
+		return true, nil
+	case apierrors.IsNotFound(err), discovery.IsGroupDiscoveryFailedError(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// defaultLeaderIdentity mirrors client-go's own recommended default: the
+// pod name if running in-cluster (set via the downward API), falling back
+// to hostname+UID so two replicas on the same node still get distinct
+// identities.
+func defaultLeaderIdentity() string {
+	if podName := os.Getenv("POD_NAME"); podName != "" {
+		return podName
+	}
+
+	hostname, err := os.Hostname()
 	if err != nil {
-		logger.Error("List interface addresses", "err", err)
-		return fallback
+		return string(uuid.NewUUID())
 	}
+	return hostname + "_" + string(uuid.NewUUID())
+}
 
-	for _, addr := range addrs {
-		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
-			if ipv4 := ipnet.IP.To4(); ipv4 != nil {
-				logger.Info("Using ip for mDNS", "ip", ipv4.String())
-				return ipv4.String()
-			}
+// buildIPResolver picks the address source based on --publish-service /
+// --publish-address, falling back to scanning local interfaces when neither
+// is set. ipAddressExplicit is true when the caller passed --ip-address
+// explicitly, in which case it overrides whatever the interface scan would
+// have found instead of only being used as a last-resort fallback.
+func buildIPResolver(
+	logger *slog.Logger,
+	clientset kubernetes.Interface,
+	publishService string,
+	publishAddresses []string,
+	ipAddress string,
+	ipAddressExplicit bool,
+) (ipresolver.IPResolver, error) {
+	if publishService != "" && len(publishAddresses) > 0 {
+		return nil, fmt.Errorf("--publish-service and --publish-address are mutually exclusive")
+	}
+
+	if publishService != "" {
+		namespace, name, found := strings.Cut(publishService, "/")
+		if !found {
+			return nil, fmt.Errorf("--publish-service must be in <namespace>/<name> form, got %q", publishService)
 		}
+		return ipresolver.NewServiceResolver(clientset, logger, namespace, name), nil
+	}
+
+	if len(publishAddresses) > 0 {
+		return ipresolver.NewStaticResolver(publishAddresses), nil
 	}
 
-	logger.Warn("No IPv4 addresses found")
-	return fallback
+	return ipresolver.NewInterfaceScanResolver(logger, ipAddress, ipAddressExplicit), nil
 }
 
 func main() {
@@ -46,6 +142,17 @@ func main() {
 	configFlags := genericclioptions.NewConfigFlags(true)
 
 	var ipAddress string
+	var backend string
+	var publishService string
+	var publishAddress []string
+	var enableGatewayAPI string
+	var leaderElect bool
+	var leaderElectLeaseDuration time.Duration
+	var leaderElectRenewDeadline time.Duration
+	var leaderElectRetryPeriod time.Duration
+	var leaderElectResourceName string
+	var leaderElectResourceNamespace string
+	var leaderElectIdentity string
 	cmd := &cobra.Command{
 		Use:   "mdnscontroller",
 		Short: "Watches ingresses for hosts and registers them.",
@@ -62,48 +169,189 @@ func main() {
 				os.Exit(1)
 			}
 
+			gwapiClientset, err := gwapiversioned.NewForConfig(config)
+			if err != nil {
+				logger.Error("create gateway API clientset", "err", err)
+				os.Exit(1)
+			}
+
 			// Determine namespace (empty string == all namespaces)
 			namespace, _, _ := configFlags.ToRawKubeConfigLoader().Namespace()
 
 			logger.Info("Starting controller", "namespace", namespace)
 
-			if !cmd.Flags().Changed("ip-address") {
-				ipAddress = GetLocalAddressOr(logger, ipAddress)
-			}
+			// runController wires up the IP resolver, mDNS backend, and
+			// watched sources, then blocks on the controller's reconcile
+			// loop until ctx is canceled. It's only ever invoked while
+			// holding leadership (or directly, if --leader-elect is off),
+			// so every `dns-sd`/Avahi child process it spawns is torn
+			// down whenever ctx goes away.
+			runController := func(ctx context.Context) {
+				resolver, err := buildIPResolver(logger, clientset, publishService, publishAddress, ipAddress, cmd.Flags().Changed("ip-address"))
+				if err != nil {
+					logger.Error("build IP resolver", "err", err)
+					os.Exit(1)
+				}
+
+				// Create Informer Factories
+				// Re-sync every 10 mins ensures the cache doesn't drift
+				factory := informers.NewSharedInformerFactoryWithOptions(
+					clientset,
+					10*time.Minute,
+					informers.WithNamespace(namespace),
+				)
+
+				go func() {
+					if err := resolver.Run(ctx); err != nil {
+						logger.Error("run IP resolver", "err", err)
+					}
+				}()
+
+				resolvedBackend, err := resolveBackend(backend, runtime.GOOS)
+				if err != nil {
+					logger.Error("resolve backend", "err", err)
+					os.Exit(1)
+				}
+
+				var handler controller.HostHandler
+				switch resolvedBackend {
+				case "dns-sd":
+					handler = mdns.NewMacHandler(ctx, logger, resolver)
+				case "avahi":
+					handler = mdns.NewLinuxHandler(ctx, logger, resolver)
+				case "native":
+					handler = mdns.NewGoHandler(logger, resolver)
+				default:
+					logger.Error("Unknown backend", "backend", resolvedBackend)
+					os.Exit(1)
+				}
+
+				logger.Info("Using mDNS backend", "backend", resolvedBackend)
+
+				gatewayAPIEnabled, err := resolveGatewayAPIEnabled(enableGatewayAPI, func() (bool, error) {
+					return gatewayAPIAvailable(clientset.Discovery())
+				})
+				if err != nil {
+					logger.Error("resolve --enable-gateway-api", "err", err)
+					os.Exit(1)
+				}
+
+				sources := []controller.Source{
+					controller.NewIngressSource(factory),
+				}
+
+				if gatewayAPIEnabled {
+					gwapiFactory := gwapiinformers.NewSharedInformerFactoryWithOptions(
+						gwapiClientset,
+						10*time.Minute,
+						gwapiinformers.WithNamespace(namespace),
+					)
+					sources = append(sources,
+						controller.NewGatewaySource(gwapiFactory),
+						controller.NewHTTPRouteSource(gwapiFactory, gwapiFactory.Gateway().V1().Gateways().Lister()),
+					)
+					logger.Info("Gateway API support enabled")
+				} else {
+					logger.Info("Gateway API support disabled, skipping Gateway/HTTPRoute sources")
+				}
 
-			// Create Informer Factory
-			// Re-sync every 10 mins ensures the cache doesn't drift
-			factory := informers.NewSharedInformerFactoryWithOptions(
-				clientset,
-				10*time.Minute,
-				informers.WithNamespace(namespace),
-			)
+				mdnsController := controller.NewMDNS(sources, handler, logger)
+
+				// Handle crash inside the informer routines
+				defer k8sruntime.HandleCrash()
+
+				if err := mdnsController.Run(ctx); err != nil {
+					logger.Error("Error running controller", "err", err)
+					os.Exit(1)
+				}
+			}
 
 			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 			defer cancel()
 
-			var handler controller.HostHandler
-			switch operatingSystem := runtime.GOOS; operatingSystem {
-			case "darwin":
-				handler = mdns.NewMacHandler(ctx, logger, ipAddress)
-			default:
-				logger.Error("Not implemented for", "os", operatingSystem)
-				os.Exit(1)
+			if !leaderElect {
+				runController(ctx)
+				return
 			}
 
-			controller := controller.NewMDNS(factory, handler, logger)
+			identity := leaderElectIdentity
+			if identity == "" {
+				identity = defaultLeaderIdentity()
+			}
 
-			// Handle crash inside the informer routines
-			defer k8sruntime.HandleCrash()
+			leaseNamespace := leaderElectResourceNamespace
+			if leaseNamespace == "" {
+				leaseNamespace = namespace
+			}
+			if leaseNamespace == "" {
+				leaseNamespace = "default"
+			}
 
-			if err := controller.Run(ctx); err != nil {
-				logger.Error("Error running controller", "err", err)
-				os.Exit(1)
+			lock := &resourcelock.LeaseLock{
+				LeaseMeta: metav1.ObjectMeta{
+					Name:      leaderElectResourceName,
+					Namespace: leaseNamespace,
+				},
+				Client: clientset.CoordinationV1(),
+				LockConfig: resourcelock.ResourceLockConfig{
+					Identity: identity,
+				},
 			}
+
+			logger.Info("Leader election enabled", "identity", identity, "lease", leaseNamespace+"/"+leaderElectResourceName)
+
+			// OnStartedLeading runs in its own goroutine (client-go calls it
+			// via `go`) while OnStoppedLeading runs in the renewal
+			// goroutine, so the cancel func they share needs a lock rather
+			// than a bare variable.
+			var controllerCancelMu sync.Mutex
+			var controllerCancel context.CancelFunc
+			leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+				Lock:            lock,
+				ReleaseOnCancel: true,
+				LeaseDuration:   leaderElectLeaseDuration,
+				RenewDeadline:   leaderElectRenewDeadline,
+				RetryPeriod:     leaderElectRetryPeriod,
+				Callbacks: leaderelection.LeaderCallbacks{
+					OnStartedLeading: func(leCtx context.Context) {
+						logger.Info("Acquired leadership, starting controller", "identity", identity)
+						controllerCtx, cancel := context.WithCancel(leCtx)
+						controllerCancelMu.Lock()
+						controllerCancel = cancel
+						controllerCancelMu.Unlock()
+						runController(controllerCtx)
+					},
+					OnStoppedLeading: func() {
+						logger.Info("Lost leadership, stopping controller", "identity", identity)
+						controllerCancelMu.Lock()
+						cancel := controllerCancel
+						controllerCancelMu.Unlock()
+						if cancel != nil {
+							cancel()
+						}
+					},
+					OnNewLeader: func(currentID string) {
+						if currentID != identity {
+							logger.Info("Observed new leader", "leader", currentID)
+						}
+					},
+				},
+			})
 		},
 	}
 
-	cmd.Flags().StringVar(&ipAddress, "ip-address", "<defaults to first ipv4 found>", "IP address to advertise (auto-detected if not specified)")
+	cmd.Flags().StringVar(&ipAddress, "ip-address", "<defaults to first ipv4 found>", "IP address to advertise when neither --publish-service nor --publish-address is set (auto-detected from local interfaces if not specified)")
+	cmd.Flags().StringVar(&backend, "backend", "auto", "mDNS backend to use: auto, dns-sd (macOS), avahi (Linux), or native (pure-Go, works in scratch/distroless containers)")
+	cmd.Flags().StringVar(&publishService, "publish-service", "", "Watch <namespace>/<name> Service and publish its load balancer address(es) (mutually exclusive with --publish-address)")
+	cmd.Flags().StringSliceVar(&publishAddress, "publish-address", nil, "Static address(es) to publish instead of scanning local interfaces (mutually exclusive with --publish-service)")
+	cmd.Flags().StringVar(&enableGatewayAPI, "enable-gateway-api", "auto", "Watch Gateway API Gateway/HTTPRoute objects: auto (probe discovery, skip if the CRDs aren't installed), true, or false")
+	cmd.Flags().BoolVar(&leaderElect, "leader-elect", false, "Use leader election so only one replica registers hosts at a time")
+	cmd.Flags().DurationVar(&leaderElectLeaseDuration, "leader-elect-lease-duration", 15*time.Second, "Duration a leadership lease is valid for before it must be renewed")
+	cmd.Flags().DurationVar(&leaderElectRenewDeadline, "leader-elect-renew-deadline", 10*time.Second, "Duration the leader will retry refreshing its lease before giving up")
+	cmd.Flags().DurationVar(&leaderElectRetryPeriod, "leader-elect-retry-period", 2*time.Second, "Duration non-leader candidates wait between acquisition attempts")
+	cmd.Flags().StringVar(&leaderElectResourceName, "leader-elect-resource-name", "mdnscontroller-leader", "Name of the Lease object used for leader election")
+	cmd.Flags().StringVar(&leaderElectResourceNamespace, "leader-elect-resource-namespace", "", "Namespace of the Lease object used for leader election (defaults to the watched namespace, or \"default\")")
+	cmd.Flags().StringVar(&leaderElectIdentity, "leader-elect-identity", "", "Identity to use when acquiring the leadership lease (defaults to $POD_NAME, falling back to hostname+UID)")
 	configFlags.AddFlags(cmd.Flags())
 
 	if err := cmd.Execute(); err != nil {
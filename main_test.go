@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestResolveBackend(t *testing.T) {
+	tests := []struct {
+		name            string
+		backend         string
+		operatingSystem string
+		want            string
+		wantErr         bool
+	}{
+		{name: "explicit dns-sd", backend: "dns-sd", operatingSystem: "linux", want: "dns-sd"},
+		{name: "explicit avahi", backend: "avahi", operatingSystem: "darwin", want: "avahi"},
+		{name: "explicit native", backend: "native", operatingSystem: "linux", want: "native"},
+		{name: "auto on darwin", backend: "auto", operatingSystem: "darwin", want: "dns-sd"},
+		{name: "auto on linux", backend: "auto", operatingSystem: "linux", want: "avahi"},
+		{name: "auto elsewhere", backend: "auto", operatingSystem: "windows", want: "native"},
+		{name: "unknown backend", backend: "bogus", operatingSystem: "linux", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveBackend(tt.backend, tt.operatingSystem)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveBackend(%q, %q) expected an error, got %q", tt.backend, tt.operatingSystem, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveBackend(%q, %q): %v", tt.backend, tt.operatingSystem, err)
+			}
+			if got != tt.want {
+				t.Fatalf("resolveBackend(%q, %q) = %q, want %q", tt.backend, tt.operatingSystem, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveGatewayAPIEnabled(t *testing.T) {
+	probeErr := errors.New("probe failed")
+
+	tests := []struct {
+		name    string
+		mode    string
+		probe   func() (bool, error)
+		want    bool
+		wantErr bool
+	}{
+		{name: "true", mode: "true", want: true},
+		{name: "false", mode: "false", want: false},
+		{name: "auto delegates to probe", mode: "auto", probe: func() (bool, error) { return true, nil }, want: true},
+		{name: "auto propagates probe error", mode: "auto", probe: func() (bool, error) { return false, probeErr }, wantErr: true},
+		{name: "unknown mode", mode: "bogus", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := tt.probe
+			if probe == nil {
+				probe = func() (bool, error) {
+					t.Fatal("probe should not be called for a non-auto mode")
+					return false, nil
+				}
+			}
+
+			got, err := resolveGatewayAPIEnabled(tt.mode, probe)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveGatewayAPIEnabled(%q) expected an error, got %v", tt.mode, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveGatewayAPIEnabled(%q): %v", tt.mode, err)
+			}
+			if got != tt.want {
+				t.Fatalf("resolveGatewayAPIEnabled(%q) = %v, want %v", tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGatewayAPIAvailable(t *testing.T) {
+	groupVersion, err := schema.ParseGroupVersion(gatewayAPIGroupVersion)
+	if err != nil {
+		t.Fatalf("parse group version: %v", err)
+	}
+
+	t.Run("installed", func(t *testing.T) {
+		disco := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		disco.Resources = []*metav1.APIResourceList{{GroupVersion: gatewayAPIGroupVersion}}
+
+		got, err := gatewayAPIAvailable(disco)
+		if err != nil {
+			t.Fatalf("gatewayAPIAvailable: %v", err)
+		}
+		if !got {
+			t.Fatal("expected Gateway API to be reported available")
+		}
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		disco := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		disco.PrependReactor("get", "resource", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewNotFound(groupVersion.WithResource("").GroupResource(), gatewayAPIGroupVersion)
+		})
+
+		got, err := gatewayAPIAvailable(disco)
+		if err != nil {
+			t.Fatalf("gatewayAPIAvailable: %v", err)
+		}
+		if got {
+			t.Fatal("expected Gateway API to be reported unavailable, not an error")
+		}
+	})
+
+	t.Run("discovery error propagates", func(t *testing.T) {
+		wantErr := errors.New("discovery server unreachable")
+		disco := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		disco.PrependReactor("get", "resource", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, wantErr
+		})
+
+		_, err := gatewayAPIAvailable(disco)
+		if err == nil {
+			t.Fatal("expected discovery error to propagate")
+		}
+	})
+}
+
+var _ discovery.DiscoveryInterface = (*fakediscovery.FakeDiscovery)(nil)
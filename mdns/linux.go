@@ -0,0 +1,175 @@
+package mdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	"github.com/DeluxeOwl/mdnscontroller/controller"
+	"github.com/DeluxeOwl/mdnscontroller/ipresolver"
+)
+
+const (
+	AvahiPublishBinary = "avahi-publish"
+)
+
+// LinuxHandler registers hosts with the system's Avahi daemon by spawning,
+// per host, a long-lived `avahi-publish -a -R` process to bind the address
+// and a `avahi-publish -s` process to advertise the service (type, port,
+// TXT), mirroring the process-map pattern used by MacHandler for dns-sd.
+type LinuxHandler struct {
+	ctx      context.Context
+	logger   *slog.Logger
+	resolver ipresolver.IPResolver
+
+	mu sync.Mutex
+
+	processes map[string][]context.CancelFunc
+	specs     map[string]controller.HostSpec
+}
+
+func NewLinuxHandler(ctx context.Context, logger *slog.Logger, resolver ipresolver.IPResolver) *LinuxHandler {
+	lh := &LinuxHandler{
+		ctx:       ctx,
+		logger:    logger,
+		resolver:  resolver,
+		processes: make(map[string][]context.CancelFunc),
+		specs:     make(map[string]controller.HostSpec),
+	}
+	resolver.Subscribe(lh.onAddressesChanged)
+	return lh
+}
+
+func (lh *LinuxHandler) OnHostsAdded(hosts []controller.HostSpec) error {
+	lh.logger.Info("Registering hosts", "hosts", hosts)
+
+	var errs []error
+	for _, spec := range hosts {
+		if err := lh.startHost(spec); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (lh *LinuxHandler) OnHostsRemoved(hosts []controller.HostSpec) error {
+	lh.logger.Info("Unregistering hosts", "hosts", hosts)
+
+	for _, spec := range hosts {
+		lh.stopHost(spec.Host)
+	}
+	return nil
+}
+
+// onAddressesChanged re-publishes every currently-registered host so it
+// picks up the new address set, e.g. when the watched Service's load
+// balancer IP changes.
+func (lh *LinuxHandler) onAddressesChanged(addresses []string) {
+	lh.mu.Lock()
+	specs := make([]controller.HostSpec, 0, len(lh.specs))
+	for _, spec := range lh.specs {
+		specs = append(specs, spec)
+	}
+	lh.mu.Unlock()
+
+	if len(specs) == 0 {
+		return
+	}
+
+	lh.logger.Info("Address set changed, re-publishing hosts", "addresses", addresses, "hosts", specs)
+	for _, spec := range specs {
+		lh.stopHost(spec.Host)
+		if err := lh.startHost(spec); err != nil {
+			lh.logger.Error("Re-publish host after address change", "host", spec.Host, "err", err)
+		}
+	}
+}
+
+func (lh *LinuxHandler) startHost(spec controller.HostSpec) error {
+	lh.mu.Lock()
+	defer lh.mu.Unlock()
+
+	if _, exists := lh.processes[spec.Host]; exists {
+		lh.logger.Info("Host already registered, skipping", "host", spec.Host)
+		return nil
+	}
+
+	addresses := lh.resolver.Addresses()
+	if len(addresses) == 0 {
+		return fmt.Errorf("no address available to publish host %q", spec.Host)
+	}
+	ipAddress := addresses[0]
+
+	hostCtx, cancel := context.WithCancel(lh.ctx)
+
+	// avahi-publish -a -R <host> <ip>
+	addressArgs := []string{"-a", "-R", spec.Host, ipAddress}
+	addressCmd := exec.CommandContext(hostCtx, AvahiPublishBinary, addressArgs...)
+
+	// avahi-publish -s <name> <type> <port> [<txt>...]
+	serviceArgs := []string{"-s", spec.InstanceName, spec.ServiceType, strconv.Itoa(spec.Port)}
+	serviceArgs = append(serviceArgs, spec.TXTPairs()...)
+	serviceCmd := exec.CommandContext(hostCtx, AvahiPublishBinary, serviceArgs...)
+
+	lh.logger.Info("Starting avahi-publish processes", "host", spec.Host, "address-args", addressArgs, "service-args", serviceArgs)
+
+	if err := addressCmd.Start(); err != nil {
+		cancel()
+		return err
+	}
+	if err := serviceCmd.Start(); err != nil {
+		cancel()
+		_ = addressCmd.Wait()
+		return err
+	}
+
+	lh.processes[spec.Host] = []context.CancelFunc{cancel}
+	lh.specs[spec.Host] = spec
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for _, cmd := range []*exec.Cmd{addressCmd, serviceCmd} {
+		go func(h string, c *exec.Cmd) {
+			defer wg.Done()
+			if err := c.Wait(); err != nil {
+				if hostCtx.Err() == context.Canceled {
+					lh.logger.Info("avahi-publish process stopped (context canceled)", "host", h)
+				} else {
+					lh.logger.Error("avahi-publish process exited with error", "host", h, "error", err)
+				}
+			}
+		}(spec.Host, cmd)
+	}
+
+	go func(h string) {
+		wg.Wait()
+		lh.mu.Lock()
+		delete(lh.processes, h)
+		delete(lh.specs, h)
+		lh.mu.Unlock()
+	}(spec.Host)
+
+	return nil
+}
+
+func (lh *LinuxHandler) stopHost(host string) {
+	lh.mu.Lock()
+	defer lh.mu.Unlock()
+
+	cancels, exists := lh.processes[host]
+	if !exists {
+		lh.logger.Info("Host not found in registry, processing skip", "host", host)
+		return
+	}
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+
+	delete(lh.processes, host)
+	delete(lh.specs, host)
+}
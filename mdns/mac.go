@@ -2,9 +2,15 @@ package mdns
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os/exec"
+	"strconv"
 	"sync"
+
+	"github.com/DeluxeOwl/mdnscontroller/controller"
+	"github.com/DeluxeOwl/mdnscontroller/ipresolver"
 )
 
 const (
@@ -12,71 +18,116 @@ const (
 )
 
 type MacHandler struct {
-	ctx       context.Context
-	logger    *slog.Logger
-	ipAddress string
+	ctx      context.Context
+	logger   *slog.Logger
+	resolver ipresolver.IPResolver
 
 	mu sync.Mutex
 
 	processes map[string]context.CancelFunc
+	specs     map[string]controller.HostSpec
 }
 
-func NewMacHandler(ctx context.Context, logger *slog.Logger, ipAddress string) *MacHandler {
-	return &MacHandler{
+func NewMacHandler(ctx context.Context, logger *slog.Logger, resolver ipresolver.IPResolver) *MacHandler {
+	mh := &MacHandler{
 		ctx:       ctx,
 		logger:    logger,
-		ipAddress: ipAddress,
+		resolver:  resolver,
 		processes: make(map[string]context.CancelFunc),
+		specs:     make(map[string]controller.HostSpec),
 	}
+	resolver.Subscribe(mh.onAddressesChanged)
+	return mh
 }
 
-func (mh *MacHandler) OnHostsAdded(hosts []string) {
+func (mh *MacHandler) OnHostsAdded(hosts []controller.HostSpec) error {
 	mh.logger.Info("Registering hosts", "hosts", hosts)
 
-	for _, host := range hosts {
-		mh.startHost(host)
+	var errs []error
+	for _, spec := range hosts {
+		if err := mh.startHost(spec); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
 }
 
-func (mh *MacHandler) OnHostsRemoved(hosts []string) {
+func (mh *MacHandler) OnHostsRemoved(hosts []controller.HostSpec) error {
 	mh.logger.Info("Unregistering hosts", "hosts", hosts)
 
-	for _, host := range hosts {
-		mh.stopHost(host)
+	for _, spec := range hosts {
+		mh.stopHost(spec.Host)
 	}
+	return nil
 }
 
-func (mh *MacHandler) startHost(host string) {
+// onAddressesChanged re-publishes every currently-registered host so it
+// picks up the new address set, e.g. when the watched Service's load
+// balancer IP changes.
+func (mh *MacHandler) onAddressesChanged(addresses []string) {
 	mh.mu.Lock()
-	defer mh.mu.Unlock()
+	specs := make([]controller.HostSpec, 0, len(mh.specs))
+	for _, spec := range mh.specs {
+		specs = append(specs, spec)
+	}
+	mh.mu.Unlock()
 
-	if _, exists := mh.processes[host]; exists {
-		mh.logger.Info("Host already registered, skipping", "host", host)
+	if len(specs) == 0 {
 		return
 	}
 
-	hostCtx, cancel := context.WithCancel(mh.ctx)
+	mh.logger.Info("Address set changed, re-publishing hosts", "addresses", addresses, "hosts", specs)
+	for _, spec := range specs {
+		mh.stopHost(spec.Host)
+		if err := mh.startHost(spec); err != nil {
+			mh.logger.Error("Re-publish host after address change", "host", spec.Host, "err", err)
+		}
+	}
+}
+
+func (mh *MacHandler) startHost(spec controller.HostSpec) error {
+	mh.mu.Lock()
+	defer mh.mu.Unlock()
 
-	mh.processes[host] = cancel
+	if _, exists := mh.processes[spec.Host]; exists {
+		mh.logger.Info("Host already registered, skipping", "host", spec.Host)
+		return nil
+	}
+
+	addresses := mh.resolver.Addresses()
+	if len(addresses) == 0 {
+		return fmt.Errorf("no address available to publish host %q", spec.Host)
+	}
+	ipAddress := addresses[0]
 
-	// dns-sd -P <host> _http._tcp local 443 <host> <ip>
-	// Note: _tcp is generic, but requested. Usually this is _http._tcp or similar.
+	hostCtx, cancel := context.WithCancel(mh.ctx)
+
+	// dns-sd -P <name> <type> local <port> <host> <ip> [<txt>...]
 	args := []string{
-		"-P",         // Proxy mode
-		host,         // Instance Name
-		"_http._tcp", // Service Type
-		"local",      // Domain
-		"443",        // Port
-		host,         // Host Target
-		mh.ipAddress, // IP Address
+		"-P",                    // Proxy mode
+		spec.InstanceName,       // Instance Name
+		spec.ServiceType,        // Service Type
+		"local",                 // Domain
+		strconv.Itoa(spec.Port), // Port
+		spec.Host,               // Host Target
+		ipAddress,               // IP Address
 	}
+	args = append(args, spec.TXTPairs()...)
 
 	cmd := exec.CommandContext(hostCtx, DnsSDBinary, args...)
 
-	go func(h string, c *exec.Cmd) {
-		mh.logger.Info("Starting dns-sd process", "host", h, "args", args)
+	mh.logger.Info("Starting dns-sd process", "host", spec.Host, "args", args)
 
-		if err := c.Run(); err != nil {
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return err
+	}
+
+	mh.processes[spec.Host] = cancel
+	mh.specs[spec.Host] = spec
+
+	go func(h string, c *exec.Cmd) {
+		if err := c.Wait(); err != nil {
 			if hostCtx.Err() == context.Canceled {
 				mh.logger.Info("dns-sd process stopped (context canceled)", "host", h)
 			} else {
@@ -87,8 +138,11 @@ func (mh *MacHandler) startHost(host string) {
 		mh.mu.Lock()
 
 		delete(mh.processes, h)
+		delete(mh.specs, h)
 		mh.mu.Unlock()
-	}(host, cmd)
+	}(spec.Host, cmd)
+
+	return nil
 }
 
 func (mh *MacHandler) stopHost(host string) {
@@ -104,4 +158,5 @@ func (mh *MacHandler) stopHost(host string) {
 	cancel()
 
 	delete(mh.processes, host)
+	delete(mh.specs, host)
 }
@@ -0,0 +1,139 @@
+package mdns
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+
+	"github.com/hashicorp/mdns"
+
+	"github.com/DeluxeOwl/mdnscontroller/controller"
+	"github.com/DeluxeOwl/mdnscontroller/ipresolver"
+)
+
+// GoHandler registers hosts using a pure-Go multicast DNS responder, so the
+// controller can advertise hosts from scratch/distroless containers where
+// no system mDNS daemon (dns-sd, Avahi) is available.
+type GoHandler struct {
+	logger   *slog.Logger
+	resolver ipresolver.IPResolver
+
+	mu sync.Mutex
+
+	servers map[string]*mdns.Server
+	specs   map[string]controller.HostSpec
+}
+
+func NewGoHandler(logger *slog.Logger, resolver ipresolver.IPResolver) *GoHandler {
+	gh := &GoHandler{
+		logger:   logger,
+		resolver: resolver,
+		servers:  make(map[string]*mdns.Server),
+		specs:    make(map[string]controller.HostSpec),
+	}
+	resolver.Subscribe(gh.onAddressesChanged)
+	return gh
+}
+
+func (gh *GoHandler) OnHostsAdded(hosts []controller.HostSpec) error {
+	gh.logger.Info("Registering hosts", "hosts", hosts)
+
+	var errs []error
+	for _, spec := range hosts {
+		if err := gh.startHost(spec); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (gh *GoHandler) OnHostsRemoved(hosts []controller.HostSpec) error {
+	gh.logger.Info("Unregistering hosts", "hosts", hosts)
+
+	var errs []error
+	for _, spec := range hosts {
+		if err := gh.stopHost(spec.Host); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// onAddressesChanged re-publishes every currently-registered host so it
+// picks up the new address set, e.g. when the watched Service's load
+// balancer IP changes.
+func (gh *GoHandler) onAddressesChanged(addresses []string) {
+	gh.mu.Lock()
+	specs := make([]controller.HostSpec, 0, len(gh.specs))
+	for _, spec := range gh.specs {
+		specs = append(specs, spec)
+	}
+	gh.mu.Unlock()
+
+	if len(specs) == 0 {
+		return
+	}
+
+	gh.logger.Info("Address set changed, re-publishing hosts", "addresses", addresses, "hosts", specs)
+	for _, spec := range specs {
+		if err := gh.stopHost(spec.Host); err != nil {
+			gh.logger.Error("Stop host before re-publish", "host", spec.Host, "err", err)
+		}
+		if err := gh.startHost(spec); err != nil {
+			gh.logger.Error("Re-publish host after address change", "host", spec.Host, "err", err)
+		}
+	}
+}
+
+func (gh *GoHandler) startHost(spec controller.HostSpec) error {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+
+	if _, exists := gh.servers[spec.Host]; exists {
+		gh.logger.Info("Host already registered, skipping", "host", spec.Host)
+		return nil
+	}
+
+	addresses := gh.resolver.Addresses()
+	if len(addresses) == 0 {
+		return fmt.Errorf("no address available to publish host %q", spec.Host)
+	}
+
+	ip := net.ParseIP(addresses[0])
+
+	service, err := mdns.NewMDNSService(spec.InstanceName, spec.ServiceType, "", spec.Host+".", spec.Port, []net.IP{ip}, spec.TXTPairs())
+	if err != nil {
+		return err
+	}
+
+	server, err := mdns.NewServer(&mdns.Config{Zone: service})
+	if err != nil {
+		return err
+	}
+
+	gh.servers[spec.Host] = server
+	gh.specs[spec.Host] = spec
+	return nil
+}
+
+func (gh *GoHandler) stopHost(host string) error {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+
+	server, exists := gh.servers[host]
+	if !exists {
+		gh.logger.Info("Host not found in registry, processing skip", "host", host)
+		return nil
+	}
+
+	if err := server.Shutdown(); err != nil {
+		return err
+	}
+
+	delete(gh.servers, host)
+	delete(gh.specs, host)
+
+	return nil
+}